@@ -1,142 +1,2264 @@
 package checkly
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// ErrNotFound is returned when the requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnauthorized is returned when the API rejects the client's API key.
+var ErrUnauthorized = errors.New("unauthorized: check your API key")
+
+// ErrConflict is returned when the API rejects a request because it conflicts
+// with the current state of the resource.
+var ErrConflict = errors.New("conflict")
+
+// ErrGlobalAlertSettings is returned by EffectiveAlertSettings when a check
+// uses the account's global alert settings, which the API does not expose.
+var ErrGlobalAlertSettings = errors.New("check uses the account's global alert settings, which are not available from the API")
+
+// APIError is returned when the API responds with an unexpected HTTP status.
+// Message is the error message from the response, if it could be found, and
+// Body is the complete response body.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("unexpected response status %d: %q", e.StatusCode, e.Body)
+}
+
+// Is reports whether the error matches target, so that errors.Is(err,
+// ErrNotFound), errors.Is(err, ErrUnauthorized), and errors.Is(err,
+// ErrConflict) work for API errors with the corresponding status.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// ChannelInUseError is returned by DeleteAlertChannel when checks or groups
+// are still subscribed to the alert channel, so that deleting it would
+// silently stop their alerts. CheckIDs and GroupIDs list the subscribers.
+type ChannelInUseError struct {
+	ChannelID int64
+	CheckIDs  []string
+	GroupIDs  []int64
+}
+
+func (e *ChannelInUseError) Error() string {
+	return fmt.Sprintf("alert channel %d is in use by %d checks and %d groups", e.ChannelID, len(e.CheckIDs), len(e.GroupIDs))
+}
+
+// newAPIError returns an APIError for the specified response status and body,
+// extracting the error message from either of the forms the API uses:
+//
+//	{"message": "..."}
+//	{"errors": ["...", {"message": "..."}]}
+func newAPIError(status int, body string) *APIError {
+	e := &APIError{
+		StatusCode: status,
+		Body:       body,
+	}
+	var envelope struct {
+		Message string            `json:"message"`
+		Errors  []json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return e
+	}
+	if envelope.Message != "" {
+		e.Message = envelope.Message
+		return e
+	}
+	var messages []string
+	for _, raw := range envelope.Errors {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			messages = append(messages, s)
+			continue
+		}
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &obj) == nil && obj.Message != "" {
+			messages = append(messages, obj.Message)
+		}
+	}
+	e.Message = strings.Join(messages, "; ")
+	return e
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
 	}
-	return fallback
+	return fallback
+}
+
+// NewClient takes a Checkly API key, and returns a Client ready to use.
+func NewClient(apiKey string) Client {
+	limits := map[string]int{}
+	for plan, max := range defaultMaxLocationsByPlan {
+		limits[plan] = max
+	}
+	return Client{
+		apiKey:             apiKey,
+		URL:                getEnv("CHECKLY_API_URL", "https://api.checklyhq.com"),
+		HTTPClient:         http.DefaultClient,
+		APIVersion:         DefaultAPIVersion,
+		CatalogTTL:         DefaultCatalogTTL,
+		MaxLocationsByPlan: limits,
+		catalogs:           &catalogCache{},
+	}
+}
+
+// Create creates a new check with the specified details. It returns the
+// check ID of the newly-created check, or an error. Since there is no real
+// value to keep, it returns an error if any environment variable has a masked
+// value (see EnvironmentVariable.Masked).
+func (c *Client) Create(check Check) (string, error) {
+	if err := checkMasked(check.EnvironmentVariables); err != nil {
+		return "", err
+	}
+	result, err := post[Check](c, checksEndpoint(check.Type), check)
+	if err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// Update updates an existing check with the specified details. It returns a
+// non-nil error if the request failed. Since the API returns the values of
+// locked environment variables masked, a check fetched with Get may contain
+// masked values. Sending these back would overwrite the real secrets, so if
+// any variable is masked, Update fetches the check, and if its variables are
+// unchanged, leaves them out of the update. If they have been changed, it
+// returns an error naming the masked variable, since the changes can't be
+// sent without it: set its real value to update the variables.
+//
+// The API does not support conditional requests (ETag and If-Match), so
+// Update cannot detect whether the check has changed since it was fetched:
+// the last update wins. If the API does reject an update with 409 Conflict,
+// the error matches ErrConflict.
+func (c *Client) Update(ID string, check Check) error {
+	var body interface{} = check
+	if hasMasked(check.EnvironmentVariables) {
+		current, err := c.Get(ID)
+		if err != nil {
+			return err
+		}
+		if !sameVariables(check.EnvironmentVariables, current.EnvironmentVariables) {
+			return checkMasked(check.EnvironmentVariables)
+		}
+		fields, err := withoutField(check, "environmentVariables")
+		if err != nil {
+			return err
+		}
+		body = fields
+	}
+	_, err := put[Check](c, checksEndpoint(check.Type)+"/"+ID, body)
+	return err
+}
+
+// checksEndpoint returns the API endpoint for creating and updating checks of
+// the specified type. TCP and heartbeat checks have their own endpoints, since
+// they take a different set of fields.
+func checksEndpoint(checkType string) string {
+	switch checkType {
+	case TypeTCP:
+		return "checks/tcp"
+	case TypeHeartbeat:
+		return "checks/heartbeat"
+	default:
+		return "checks"
+	}
+}
+
+// Delete deletes the check with the specified ID. It returns a non-nil
+// error if the request failed.
+func (c *Client) Delete(ID string) error {
+	status, res, err := c.MakeAPICall(http.MethodDelete, "checks/"+ID, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNoContent {
+		return newAPIError(status, res)
+	}
+	return nil
+}
+
+// Get takes the ID of an existing check, and returns the check parameters, or
+// an error.
+func (c *Client) Get(ID string) (Check, error) {
+	return get[Check](c, "checks/"+ID)
+}
+
+// listPageSize is the number of items requested per page when listing
+// resources. This is the maximum page size the API allows.
+const listPageSize = 100
+
+// SetActivated activates or deactivates the check with the specified ID. It
+// returns a non-nil error if the request failed.
+func (c *Client) SetActivated(ID string, activated bool) error {
+	check, err := c.Get(ID)
+	if err != nil {
+		return err
+	}
+	check.Activated = activated
+	return c.Update(ID, check)
+}
+
+// Rename changes the name of the check with the specified ID to newName. The
+// API has no PATCH method, but its PUT endpoint accepts a partial check and
+// leaves any fields not sent unchanged, so Rename sends only the name. Unlike
+// fetching the check, changing its name, and calling Update, this can't
+// overwrite changes made to other fields in the meantime.
+func (c *Client) Rename(ID, newName string) error {
+	if newName == "" {
+		return errors.New("check name must not be empty")
+	}
+	_, err := put[Check](c, "checks/"+ID, map[string]string{"name": newName})
+	return err
+}
+
+// SetActivatedByTag activates or deactivates every check with the specified
+// tag (for example, to pause all the checks for a service during
+// maintenance), and returns the number of checks changed. Checks already in
+// the requested state are left alone. If any check could not be changed, it
+// carries on with the rest, and then returns an error listing the failures.
+func (c *Client) SetActivatedByTag(tag string, activated bool) (int, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	var failures []string
+	for _, check := range checks {
+		if check.Activated == activated || !hasTags(check.Tags, []string{tag}) {
+			continue
+		}
+		if err := c.SetActivated(check.ID, activated); err != nil {
+			failures = append(failures, fmt.Sprintf("%q: %v", check.Name, err))
+			continue
+		}
+		changed++
+	}
+	if len(failures) > 0 {
+		return changed, fmt.Errorf("failed to update %d checks: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return changed, nil
+}
+
+// ListChecks returns all the checks in the account, or an error. It makes as
+// many API calls as necessary to fetch every page of results.
+func (c *Client) ListChecks() ([]Check, error) {
+	return c.listChecks("checks")
+}
+
+// ListChecksWithOptions returns all the checks in the account, like
+// ListChecks, ordered as specified by opts, or an error. The API doesn't
+// support sorting, so the checks are sorted after they have been fetched. If
+// opts.SortBy is empty, they are returned in the API's default order.
+func (c *Client) ListChecksWithOptions(opts ListOptions) ([]Check, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return nil, err
+	}
+	var less func(a, b Check) bool
+	switch opts.SortBy {
+	case "":
+		return checks, nil
+	case SortByName:
+		less = func(a, b Check) bool { return a.Name < b.Name }
+	case SortByCreatedAt:
+		less = func(a, b Check) bool { return a.CreatedAt.Before(b.CreatedAt.Time) }
+	case SortByUpdatedAt:
+		less = func(a, b Check) bool { return a.UpdatedAt.Before(b.UpdatedAt.Time) }
+	default:
+		return nil, fmt.Errorf("unknown sort field %q", opts.SortBy)
+	}
+	switch opts.SortDir {
+	case SortAsc, "":
+	case SortDesc:
+		asc := less
+		less = func(a, b Check) bool { return asc(b, a) }
+	default:
+		return nil, fmt.Errorf("unknown sort direction %q", opts.SortDir)
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return less(checks[i], checks[j])
+	})
+	return checks, nil
+}
+
+// ListModifiedSince returns the checks which have been created or updated
+// since t, or an error, so that a sync can skip checks it has already
+// processed. A check which has never been updated counts as modified when it
+// was created. The API can't filter checks by modification time, so this
+// fetches every check, just as ListChecks does, and filters them afterwards:
+// it saves work for the caller, but not API calls.
+func (c *Client) ListModifiedSince(t time.Time) ([]Check, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return nil, err
+	}
+	modified := []Check{}
+	for _, check := range checks {
+		updated := check.UpdatedAt
+		if updated.IsZero() {
+			updated = check.CreatedAt
+		}
+		if updated.After(t) {
+			modified = append(modified, check)
+		}
+	}
+	return modified, nil
+}
+
+// ListChecksByGroup returns all the checks in the group with the specified
+// ID, or an error. If the group has no checks, it returns an empty slice.
+func (c *Client) ListChecksByGroup(groupID int64) ([]Check, error) {
+	return c.listChecks(fmt.Sprintf("check-groups/%d/checks", groupID))
+}
+
+// listChecks fetches every page of checks from the specified API endpoint.
+func (c *Client) listChecks(endpoint string) ([]Check, error) {
+	checks := []Check{}
+	for page := 1; ; page++ {
+		URL := fmt.Sprintf("%s?limit=%d&page=%d", endpoint, listPageSize, page)
+		result, err := get[[]Check](c, URL)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, result...)
+		if len(result) < listPageSize {
+			return checks, nil
+		}
+	}
+}
+
+// CheckNames returns a map from check ID to check name for each of the
+// specified IDs, or an error. It lists all checks with a single call to
+// ListChecks, rather than fetching each check separately. IDs which don't
+// match any check are omitted from the map.
+func (c *Client) CheckNames(IDs []string) (map[string]string, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(checks))
+	for _, check := range checks {
+		names[check.ID] = check.Name
+	}
+	result := make(map[string]string, len(IDs))
+	for _, ID := range IDs {
+		if name, ok := names[ID]; ok {
+			result[ID] = name
+		}
+	}
+	return result, nil
+}
+
+// SubscribeByName subscribes the check with the specified ID to the alert
+// channel with the specified name (see AlertChannel.Name), with the
+// subscription activated or not as specified, and updates the check. If the
+// check is already subscribed to the channel, the subscription is updated. It
+// returns an error wrapping ErrNotFound if no channel has that name, or an
+// error if more than one does.
+func (c *Client) SubscribeByName(checkID, channelName string, activated bool) error {
+	channels, err := c.ListAlertChannels()
+	if err != nil {
+		return err
+	}
+	var matches []int64
+	for _, ch := range channels {
+		if ch.Name() == channelName {
+			matches = append(matches, ch.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("alert channel %q: %w", channelName, ErrNotFound)
+	case 1:
+	default:
+		return fmt.Errorf("alert channel name %q is ambiguous (matches channel IDs %v)", channelName, matches)
+	}
+	check, err := c.Get(checkID)
+	if err != nil {
+		return err
+	}
+	check.AlertChannelSubscriptions = subscribe(check.AlertChannelSubscriptions, matches[0], activated)
+	return c.Update(checkID, check)
+}
+
+// ListSubscriptions returns the alert channel subscriptions of every check in
+// the account, with the CheckID field of each subscription set to the ID of
+// the subscribing check. The API has no endpoint for this, so it lists all
+// checks and collects their subscriptions.
+func (c *Client) ListSubscriptions() ([]Subscription, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return nil, err
+	}
+	subs := []Subscription{}
+	for _, check := range checks {
+		for _, s := range check.AlertChannelSubscriptions {
+			s.CheckID = check.ID
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+// catalogCache holds the raw responses from catalog endpoints, keyed by API
+// version and endpoint, along with the time each was fetched, and the region
+// codes set by RefreshLocations, if it has been called.
+type catalogCache struct {
+	mu      sync.Mutex
+	entries map[string]catalogEntry
+	regions []string
+}
+
+type catalogEntry struct {
+	body    string
+	fetched time.Time
+}
+
+// getCatalog returns the response body from the given catalog endpoint,
+// using a cached copy if there is one younger than c.CatalogTTL.
+func (c *Client) getCatalog(endpoint string) (string, error) {
+	if c.catalogs != nil && c.CatalogTTL > 0 {
+		c.catalogs.mu.Lock()
+		e, ok := c.catalogs.entries[c.apiVersion()+"/"+endpoint]
+		c.catalogs.mu.Unlock()
+		if ok && time.Since(e.fetched) < c.CatalogTTL {
+			return e.body, nil
+		}
+	}
+	return c.fetchCatalog(endpoint)
+}
+
+// fetchCatalog returns the response body from the given catalog endpoint,
+// always calling the API, and caches it for later calls to getCatalog.
+func (c *Client) fetchCatalog(endpoint string) (string, error) {
+	status, res, err := c.MakeAPICall(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", newAPIError(status, res)
+	}
+	if c.catalogs != nil && c.CatalogTTL > 0 {
+		key := c.apiVersion() + "/" + endpoint
+		c.catalogs.mu.Lock()
+		if c.catalogs.entries == nil {
+			c.catalogs.entries = map[string]catalogEntry{}
+		}
+		c.catalogs.entries[key] = catalogEntry{body: res, fetched: time.Now()}
+		c.catalogs.mu.Unlock()
+	}
+	return res, nil
+}
+
+// InvalidateCatalogs discards any cached catalog responses, so that the next
+// call to ListLocations or ListRuntimes fetches fresh data from the API.
+func (c *Client) InvalidateCatalogs() {
+	if c.catalogs == nil {
+		return
+	}
+	c.catalogs.mu.Lock()
+	c.catalogs.entries = nil
+	c.catalogs.mu.Unlock()
+}
+
+// ListLocations returns the locations from which checks can be run, or an
+// error.
+func (c *Client) ListLocations() ([]Location, error) {
+	res, err := c.getCatalog("locations")
+	if err != nil {
+		return nil, err
+	}
+	return decode[[]Location](res)
+}
+
+// AllLocationCodes returns the region codes of every location from which
+// checks can be run, such as "eu-west-1", or an error. Like ListLocations, it
+// uses the cached catalog if it is fresh, so it can be called often.
+func (c *Client) AllLocationCodes() ([]string, error) {
+	locations, err := c.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+	return regionCodes(locations), nil
+}
+
+// RefreshLocations fetches the current list of locations from the API,
+// bypassing any cached copy, and uses it to update the regions consulted by
+// LocationsInGroup, so that newly added regions are included. If the call
+// fails, the existing regions are left unchanged. The regions are shared by
+// copies of the client, but not by other clients.
+func (c *Client) RefreshLocations() error {
+	if c.catalogs == nil {
+		return errors.New("client has no catalog cache (use NewClient to create it)")
+	}
+	res, err := c.fetchCatalog("locations")
+	if err != nil {
+		return err
+	}
+	locations, err := decode[[]Location](res)
+	if err != nil {
+		return err
+	}
+	c.catalogs.mu.Lock()
+	c.catalogs.regions = regionCodes(locations)
+	c.catalogs.mu.Unlock()
+	return nil
+}
+
+// LocationsInGroup returns the sorted region codes belonging to the given
+// region group, such as "eu", "us", or "ap" (the part of the code before the
+// first hyphen), for use as a check's Locations. Matching is
+// case-insensitive. If there are no such regions, the result is empty. The
+// regions are those fetched by the last call to RefreshLocations, or, if it
+// hasn't been called, the regions Checkly offered at the time of writing.
+func (c *Client) LocationsInGroup(group string) []string {
+	all := defaultRegions
+	if c.catalogs != nil {
+		c.catalogs.mu.Lock()
+		if c.catalogs.regions != nil {
+			all = c.catalogs.regions
+		}
+		c.catalogs.mu.Unlock()
+	}
+	group = strings.ToLower(group) + "-"
+	regions := []string{}
+	for _, r := range all {
+		if strings.HasPrefix(r, group) {
+			regions = append(regions, r)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// ListRuntimes returns the runtimes available for running checks, or an
+// error.
+func (c *Client) ListRuntimes() ([]Runtime, error) {
+	res, err := c.getCatalog("runtimes")
+	if err != nil {
+		return nil, err
+	}
+	return decode[[]Runtime](res)
+}
+
+// ListAlertChannels returns all the alert channels configured for the
+// account, or an error. It makes as many API calls as necessary to fetch every
+// page of results.
+func (c *Client) ListAlertChannels() ([]AlertChannel, error) {
+	channels := []AlertChannel{}
+	for page := 1; ; page++ {
+		result, err := c.ListAlertChannelsPage(page, listPageSize)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, result...)
+		if len(result) < listPageSize {
+			return channels, nil
+		}
+	}
+}
+
+// ListAlertChannelsByTag returns the alert channels which have the specified
+// tag, or an error. As with checks, tags must match exactly. The API can't
+// filter alert channels by tag, so this fetches every channel, like
+// ListAlertChannels, and filters them afterwards.
+func (c *Client) ListAlertChannelsByTag(tag string) ([]AlertChannel, error) {
+	channels, err := c.ListAlertChannels()
+	if err != nil {
+		return nil, err
+	}
+	tagged := []AlertChannel{}
+	for _, ch := range channels {
+		if hasTags(ch.Tags, []string{tag}) {
+			tagged = append(tagged, ch)
+		}
+	}
+	return tagged, nil
+}
+
+// ListAlertChannelsPage returns a single page of alert channels, or an error.
+// Pages are numbered from 1, and limit is the number of channels per page (at
+// most 100). A page with fewer than limit channels is the last one.
+func (c *Client) ListAlertChannelsPage(page, limit int) ([]AlertChannel, error) {
+	URL := fmt.Sprintf("alert-channels?limit=%d&page=%d", limit, page)
+	return get[[]AlertChannel](c, URL)
+}
+
+// ValidateRemote checks the check parameters for errors, like Validate, and
+// also checks its locations, runtime, and alert channel subscriptions against
+// those available in the account. This requires an API call for each of
+// these catalogs, but catches more errors than Validate alone. It also checks
+// that the number of locations is within the limit for the account's plan
+// (see Client.MaxLocationsByPlan), unless the API key can't read the account
+// details (that is, fetching them fails with ErrNotFound or ErrUnauthorized).
+// It returns a non-nil error describing the first problem found.
+func (c *Client) ValidateRemote(check Check) error {
+	if err := check.Validate(); err != nil {
+		return err
+	}
+	locations, err := c.ListLocations()
+	if err != nil {
+		return err
+	}
+	regions := map[string]bool{}
+	for _, l := range locations {
+		regions[l.Region] = true
+	}
+	for _, l := range check.Locations {
+		if !regions[l] {
+			return fmt.Errorf("unknown location %q", l)
+		}
+	}
+	account, err := c.Account()
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUnauthorized):
+	case err != nil:
+		return err
+	default:
+		if max, ok := c.MaxLocationsByPlan[account.Plan]; ok && len(check.Locations) > max {
+			return fmt.Errorf("check has %d locations, but the %s plan allows at most %d", len(check.Locations), account.Plan, max)
+		}
+	}
+	if check.RuntimeID != "" {
+		runtimes, err := c.ListRuntimes()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, r := range runtimes {
+			if r.Name == check.RuntimeID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown runtime %q", check.RuntimeID)
+		}
+	}
+	if len(check.AlertChannelSubscriptions) > 0 {
+		channels, err := c.ListAlertChannels()
+		if err != nil {
+			return err
+		}
+		IDs := map[int64]bool{}
+		for _, ch := range channels {
+			IDs[ch.ID] = true
+		}
+		for _, s := range check.AlertChannelSubscriptions {
+			if !IDs[s.AlertChannelID] {
+				return fmt.Errorf("unknown alert channel ID %d", s.AlertChannelID)
+			}
+		}
+	}
+	return nil
+}
+
+// ListSnippets returns all the snippets in the account, or an error.
+func (c *Client) ListSnippets() ([]Snippet, error) {
+	snippets := []Snippet{}
+	for page := 1; ; page++ {
+		URL := fmt.Sprintf("snippets?limit=%d&page=%d", listPageSize, page)
+		result, err := get[[]Snippet](c, URL)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, result...)
+		if len(result) < listPageSize {
+			return snippets, nil
+		}
+	}
+}
+
+// SnippetIDByName returns the ID of the snippet with the specified name. If
+// there is no such snippet, it returns ErrNotFound.
+func (c *Client) SnippetIDByName(name string) (int64, error) {
+	snippets, err := c.ListSnippets()
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range snippets {
+		if s.Name == name {
+			return s.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("snippet %q: %w", name, ErrNotFound)
+}
+
+// SnippetUsage returns the checks which use the snippet with the specified
+// ID as their setup or teardown script, or an error. If no checks use it, the
+// result is empty, so the snippet can be deleted without breaking any checks.
+// Note that check groups can also use snippets, and these are not included.
+func (c *Client) SnippetUsage(snippetID int64) ([]Check, error) {
+	checks, err := c.ListChecks()
+	if err != nil {
+		return nil, err
+	}
+	using := []Check{}
+	for _, check := range checks {
+		if check.SetupSnippetID == snippetID || check.TearDownSnippetID == snippetID {
+			using = append(using, check)
+		}
+	}
+	return using, nil
+}
+
+// SetSnippetsByName sets the setup and teardown snippets of check to the
+// snippets with the specified names. An empty name leaves the corresponding
+// snippet unchanged. If either snippet does not exist, it returns
+// ErrNotFound and check is not modified.
+func (c *Client) SetSnippetsByName(check *Check, setup, tearDown string) error {
+	setupID, tearDownID := check.SetupSnippetID, check.TearDownSnippetID
+	var err error
+	if setup != "" {
+		setupID, err = c.SnippetIDByName(setup)
+		if err != nil {
+			return err
+		}
+	}
+	if tearDown != "" {
+		tearDownID, err = c.SnippetIDByName(tearDown)
+		if err != nil {
+			return err
+		}
+	}
+	check.SetupSnippetID, check.TearDownSnippetID = setupID, tearDownID
+	return nil
+}
+
+// CreateSnippet creates a new snippet with the specified details. It returns
+// the ID of the newly-created snippet, or an error.
+func (c *Client) CreateSnippet(snippet Snippet) (int64, error) {
+	result, err := post[Snippet](c, "snippets", snippet)
+	if err != nil {
+		return 0, err
+	}
+	return result.ID, nil
+}
+
+// CreateAlertChannel creates a new alert channel with the specified details.
+// It returns the ID of the newly-created channel, or an error.
+func (c *Client) CreateAlertChannel(channel AlertChannel) (int64, error) {
+	result, err := post[AlertChannel](c, "alert-channels", channel)
+	if err != nil {
+		return 0, err
+	}
+	return result.ID, nil
+}
+
+// DeleteAlertChannel deletes the alert channel with the specified ID. Unless
+// force is true, it first checks whether any checks or groups are subscribed
+// to the channel, and if so, returns a *ChannelInUseError listing them rather
+// than deleting it. This check lists every check and group in the account.
+func (c *Client) DeleteAlertChannel(ID int64, force bool) error {
+	if !force {
+		inUse := &ChannelInUseError{ChannelID: ID}
+		checks, err := c.ListChecks()
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			for _, s := range check.AlertChannelSubscriptions {
+				if s.AlertChannelID == ID {
+					inUse.CheckIDs = append(inUse.CheckIDs, check.ID)
+					break
+				}
+			}
+		}
+		groups, err := c.ListGroups()
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			for _, s := range group.AlertChannelSubscriptions {
+				if s.AlertChannelID == ID {
+					inUse.GroupIDs = append(inUse.GroupIDs, group.ID)
+					break
+				}
+			}
+		}
+		if len(inUse.CheckIDs) > 0 || len(inUse.GroupIDs) > 0 {
+			return inUse
+		}
+	}
+	status, res, err := c.MakeAPICall(http.MethodDelete, fmt.Sprintf("alert-channels/%d", ID), nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNoContent {
+		return newAPIError(status, res)
+	}
+	return nil
+}
+
+// EffectiveAlertSettings returns the alert settings which actually apply to
+// the specified check, or an error. A check in a group takes its alert
+// settings from the group, whatever its own settings are, so the order of
+// precedence is:
+//
+//  1. the group's settings, if the check belongs to a group
+//  2. the check's own settings, if it does not
+//  3. the account's global settings, if whichever of the group or check
+//     applies has UseGlobalAlertSettings set
+//
+// The API does not expose the global settings, so in the last case
+// EffectiveAlertSettings returns ErrGlobalAlertSettings.
+func (c *Client) EffectiveAlertSettings(checkID string) (AlertSettings, error) {
+	check, err := c.Get(checkID)
+	if err != nil {
+		return AlertSettings{}, err
+	}
+	useGlobal, settings := check.UseGlobalAlertSettings, check.AlertSettings
+	if check.GroupID != 0 {
+		group, err := c.GetGroup(check.GroupID)
+		if err != nil {
+			return AlertSettings{}, err
+		}
+		useGlobal, settings = group.UseGlobalAlertSettings, group.AlertSettings
+	}
+	if useGlobal {
+		return AlertSettings{}, ErrGlobalAlertSettings
+	}
+	return settings, nil
+}
+
+// EffectiveEnvVars returns the environment variables available to the
+// specified check when it runs, sorted by key, or an error. These come from
+// three levels, and where the same key is set at more than one level, the
+// most specific wins:
+//
+//  1. the check's own variables
+//  2. the variables of the check's group, if it belongs to one
+//  3. the account-level variables
+//
+// Values of locked variables are masked, as the API returns them.
+func (c *Client) EffectiveEnvVars(checkID string) ([]EnvironmentVariable, error) {
+	check, err := c.Get(checkID)
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string]EnvironmentVariable{}
+	account, err := c.ListVariables()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range account {
+		merged[v.Key] = v
+	}
+	if check.GroupID != 0 {
+		group, err := c.GetGroup(check.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range group.EnvironmentVariables {
+			merged[v.Key] = v
+		}
+	}
+	for _, v := range check.EnvironmentVariables {
+		merged[v.Key] = v
+	}
+	vars := make([]EnvironmentVariable, 0, len(merged))
+	for _, v := range merged {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Key < vars[j].Key
+	})
+	return vars, nil
+}
+
+// ListVariables returns the account-level environment variables, which are
+// available to all checks, or an error.
+func (c *Client) ListVariables() ([]EnvironmentVariable, error) {
+	return get[[]EnvironmentVariable](c, "variables")
+}
+
+// CreateVariable creates a new account-level environment variable. It
+// returns a non-nil error if the request failed, or if the variable has a
+// masked value.
+func (c *Client) CreateVariable(variable EnvironmentVariable) error {
+	if err := checkMasked([]EnvironmentVariable{variable}); err != nil {
+		return err
+	}
+	_, err := post[EnvironmentVariable](c, "variables", variable)
+	return err
+}
+
+// ListGroups returns all the check groups in the account, or an error.
+func (c *Client) ListGroups() ([]Group, error) {
+	groups := []Group{}
+	for page := 1; ; page++ {
+		URL := fmt.Sprintf("check-groups?limit=%d&page=%d", listPageSize, page)
+		result, err := get[[]Group](c, URL)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, result...)
+		if len(result) < listPageSize {
+			return groups, nil
+		}
+	}
+}
+
+// eventualAttempts is the maximum number of times GetEventual tries to get a
+// check, and eventualDelay is the delay before the first retry, which doubles
+// for each subsequent retry.
+const (
+	eventualAttempts = 5
+	eventualDelay    = 500 * time.Millisecond
+)
+
+// GetEventual is like Get, but if the check is not found, it retries a few
+// times, waiting a little longer each time, for up to about 8 seconds in
+// total. This allows for the delay before a newly-created check becomes
+// visible to Get. It stops early if ctx is cancelled. If the check still
+// isn't found, it returns ErrNotFound.
+func (c *Client) GetEventual(ctx context.Context, ID string) (Check, error) {
+	c = c.withContext(ctx)
+	delay := eventualDelay
+	if c.pollInterval != 0 {
+		delay = c.pollInterval
+	}
+	for attempt := 1; ; attempt++ {
+		check, err := c.Get(ID)
+		if err == nil || !errors.Is(err, ErrNotFound) || attempt == eventualAttempts {
+			return check, err
+		}
+		select {
+		case <-ctx.Done():
+			return Check{}, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// CreateGroup creates a new check group with the specified details. It
+// returns the ID of the newly-created group, or an error. Like Create, it
+// returns an error if any environment variable has a masked value.
+func (c *Client) CreateGroup(group Group) (int64, error) {
+	if err := checkMasked(group.EnvironmentVariables); err != nil {
+		return 0, err
+	}
+	if group.BrowserCheckDefaults != nil {
+		if err := checkMasked(group.BrowserCheckDefaults.EnvironmentVariables); err != nil {
+			return 0, err
+		}
+	}
+	result, err := post[Group](c, "check-groups", group)
+	if err != nil {
+		return 0, err
+	}
+	return result.ID, nil
+}
+
+// UpdateGroup updates an existing check group with the specified details. It
+// returns a non-nil error if the request failed. Like Update, if any
+// environment variable of the group (or of its browser check defaults) is
+// masked, it leaves those variables out of the update if they are unchanged,
+// and otherwise returns an error naming the masked variable.
+func (c *Client) UpdateGroup(ID int64, group Group) error {
+	d := group.BrowserCheckDefaults
+	maskedDefaults := d != nil && hasMasked(d.EnvironmentVariables)
+	if maskedDefaults || hasMasked(group.EnvironmentVariables) {
+		current, err := c.GetGroup(ID)
+		if err != nil {
+			return err
+		}
+		if !sameVariables(group.EnvironmentVariables, current.EnvironmentVariables) {
+			if err := checkMasked(group.EnvironmentVariables); err != nil {
+				return err
+			}
+		}
+		if maskedDefaults {
+			var currentVars []EnvironmentVariable
+			if current.BrowserCheckDefaults != nil {
+				currentVars = current.BrowserCheckDefaults.EnvironmentVariables
+			}
+			if !sameVariables(d.EnvironmentVariables, currentVars) {
+				return checkMasked(d.EnvironmentVariables)
+			}
+			defaults := *d
+			defaults.EnvironmentVariables = nil
+			group.BrowserCheckDefaults = &defaults
+		}
+	}
+	var body interface{} = group
+	if hasMasked(group.EnvironmentVariables) {
+		fields, err := withoutField(group, "environmentVariables")
+		if err != nil {
+			return err
+		}
+		body = fields
+	}
+	_, err := put[Group](c, fmt.Sprintf("check-groups/%d", ID), body)
+	return err
+}
+
+// SetGroupMuted mutes or unmutes the check group with the specified ID. While
+// a group is muted, no alerts are sent for any of its checks, so the member
+// checks themselves don't need to be changed (for example, to silence alerts
+// during a deploy). It returns a non-nil error if the request failed.
+func (c *Client) SetGroupMuted(groupID int64, muted bool) error {
+	group, err := c.GetGroup(groupID)
+	if err != nil {
+		return err
+	}
+	group.Muted = muted
+	return c.UpdateGroup(groupID, group)
+}
+
+// DeleteGroup deletes the check group with the specified ID. It returns a
+// non-nil error if the request failed.
+func (c *Client) DeleteGroup(ID int64) error {
+	status, res, err := c.MakeAPICall(http.MethodDelete, fmt.Sprintf("check-groups/%d", ID), nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNoContent {
+		return newAPIError(status, res)
+	}
+	return nil
+}
+
+// GetGroup takes the ID of an existing check group, and returns the group
+// parameters, or an error.
+func (c *Client) GetGroup(ID int64) (Group, error) {
+	return get[Group](c, fmt.Sprintf("check-groups/%d", ID))
+}
+
+// ListCheckResults returns the results of all runs of the specified check
+// within the time window given by opts, or an error. It makes as many API
+// calls as necessary to fetch every page of results.
+func (c *Client) ListCheckResults(checkID string, opts ResultsOptions) ([]CheckResult, error) {
+	results := []CheckResult{}
+	err := c.eachCheckResultsPage(checkID, opts, func(page []CheckResult) error {
+		results = append(results, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ExportResultsNDJSON writes the results of all runs of the specified check
+// between from and to (either of which may be zero, to use the API default)
+// to w as newline-delimited JSON: one result per line, ready for piping into
+// tools such as jq. Each page of results is written as soon as it has been
+// fetched, rather than holding every result in memory. It returns an error if
+// an API call or a write fails.
+func (c *Client) ExportResultsNDJSON(w io.Writer, checkID string, from, to time.Time) error {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	err := c.eachCheckResultsPage(checkID, ResultsOptions{From: from, To: to}, func(page []CheckResult) error {
+		for _, r := range page {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return buf.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// ExportResultsCSV writes the results of all runs of the specified check
+// between from and to to w as CSV, for loading into a spreadsheet, as
+// ExportResultsNDJSON does for JSON. After a header row, there is a row for
+// each result, with the columns timestamp (the start of the run, in RFC 3339
+// format), location, success (true or false), and response_time_ms. It
+// returns an error if an API call or a write fails.
+func (c *Client) ExportResultsCSV(w io.Writer, checkID string, from, to time.Time) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "location", "success", "response_time_ms"}); err != nil {
+		return err
+	}
+	err := c.eachCheckResultsPage(checkID, ResultsOptions{From: from, To: to}, func(page []CheckResult) error {
+		for _, r := range page {
+			err := cw.Write([]string{
+				r.StartedAt.UTC().Format(time.RFC3339),
+				r.RunLocation,
+				strconv.FormatBool(r.Passed()),
+				strconv.Itoa(r.ResponseTime),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// eachCheckResultsPage fetches each page of results for the specified check
+// within the time window given by opts, calling fn with each page in turn. It
+// stops and returns the error if an API call or fn fails.
+func (c *Client) eachCheckResultsPage(checkID string, opts ResultsOptions, fn func([]CheckResult) error) error {
+	params := url.Values{}
+	if !opts.From.IsZero() {
+		params.Set("from", strconv.FormatInt(opts.From.Unix(), 10))
+	}
+	if !opts.To.IsZero() {
+		params.Set("to", strconv.FormatInt(opts.To.Unix(), 10))
+	}
+	params.Set("limit", strconv.Itoa(listPageSize))
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+		URL := "check-results/" + checkID + "?" + params.Encode()
+		result, err := get[[]CheckResult](c, URL)
+		if err != nil {
+			return err
+		}
+		if err = fn(result); err != nil {
+			return err
+		}
+		if len(result) < listPageSize {
+			return nil
+		}
+	}
+}
+
+// AlertNotifications returns the alert notifications sent for the specified
+// check within the time window given by opts, showing when each alert fired,
+// which channel it was sent to, and whether it was delivered. Pagination is
+// controlled by opts.Page and opts.Limit.
+//
+// The API may return notifications for other checks too, and these are left
+// out, so a single page (if opts.Page is set) can hold fewer than opts.Limit
+// notifications even when there are later pages. Don't treat a short page as
+// the last one: to get every notification, leave opts.Page zero.
+func (c *Client) AlertNotifications(checkID string, opts AlertNotificationsOptions) ([]AlertNotification, error) {
+	params := url.Values{}
+	params.Set("checkId", checkID)
+	if !opts.From.IsZero() {
+		params.Set("from", strconv.FormatInt(opts.From.Unix(), 10))
+	}
+	if !opts.To.IsZero() {
+		params.Set("to", strconv.FormatInt(opts.To.Unix(), 10))
+	}
+	limit := opts.Limit
+	if limit == 0 {
+		limit = listPageSize
+	}
+	params.Set("limit", strconv.Itoa(limit))
+	page := opts.Page
+	if page == 0 {
+		page = 1
+	}
+	notifications := []AlertNotification{}
+	for ; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+		result, err := get[[]AlertNotification](c, "alert-notifications?"+params.Encode())
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range result {
+			if n.CheckID == checkID {
+				notifications = append(notifications, n)
+			}
+		}
+		if opts.Page != 0 || len(result) < limit {
+			return notifications, nil
+		}
+	}
+}
+
+// Reporting returns summary statistics for each check in the account, over
+// the time window given by opts. If opts.Tags is set, only checks having all
+// of those tags are included; the tags are passed to the API, so that it can
+// filter the report, and also applied to the results, so the AND semantics
+// hold whatever matching the API does.
+func (c *Client) Reporting(opts ReportingOptions) ([]ReportEntry, error) {
+	params := url.Values{}
+	if !opts.From.IsZero() {
+		params.Set("from", strconv.FormatInt(opts.From.Unix(), 10))
+	}
+	if !opts.To.IsZero() {
+		params.Set("to", strconv.FormatInt(opts.To.Unix(), 10))
+	}
+	for _, t := range opts.Tags {
+		params.Add("filterByTags", t)
+	}
+	URL := "reporting"
+	if len(params) > 0 {
+		URL += "?" + params.Encode()
+	}
+	entries, err := get[[]ReportEntry](c, URL)
+	if err != nil {
+		return nil, err
+	}
+	report := []ReportEntry{}
+	for _, e := range entries {
+		if hasTags(e.Tags, opts.Tags) {
+			report = append(report, e)
+		}
+	}
+	return report, nil
+}
+
+// CheckMetrics returns summary statistics for the runs of the specified check
+// within the time window given by opts: the number of successful and failed
+// runs, and a histogram of response times, with buckets of opts.BucketSize.
+// The histogram covers the range from zero to the slowest response, and
+// includes empty buckets, so it can be charted directly.
+func (c *Client) CheckMetrics(checkID string, opts MetricsOptions) (Metrics, error) {
+	results, err := c.ListCheckResults(checkID, ResultsOptions{
+		From: opts.From,
+		To:   opts.To,
+	})
+	if err != nil {
+		return Metrics{}, err
+	}
+	bucketSize := opts.BucketSize
+	if bucketSize <= 0 {
+		bucketSize = DefaultBucketSize
+	}
+	m := Metrics{Runs: len(results)}
+	for _, r := range results {
+		if r.Passed() {
+			m.Successes++
+		} else {
+			m.Failures++
+		}
+		i := int(time.Duration(r.ResponseTime) * time.Millisecond / bucketSize)
+		for len(m.Buckets) <= i {
+			n := time.Duration(len(m.Buckets))
+			m.Buckets = append(m.Buckets, LatencyBucket{
+				Min: n * bucketSize,
+				Max: (n + 1) * bucketSize,
+			})
+		}
+		m.Buckets[i].Count++
+	}
+	return m, nil
 }
 
-// NewClient takes a Checkly API key, and returns a Client ready to use.
-func NewClient(apiKey string) Client {
-	return Client{
-		apiKey:     apiKey,
-		URL:        getEnv("CHECKLY_API_URL", "https://api.checklyhq.com"),
-		HTTPClient: http.DefaultClient,
+// ErrorBudget returns a report of how the specified check has performed
+// against the service level objective target (the fraction of runs which
+// should pass, such as 0.999) over the window up to now, or an error. Target
+// must be greater than 0 and less than 1. If the check has no runs in the
+// window, its success ratio is 1 and none of the budget has been spent.
+func (c *Client) ErrorBudget(checkID string, window time.Duration, target float64) (BudgetReport, error) {
+	if target <= 0 || target >= 1 {
+		return BudgetReport{}, fmt.Errorf("SLO target %v must be between 0 and 1", target)
+	}
+	to := time.Now()
+	results, err := c.ListCheckResults(checkID, ResultsOptions{
+		From: to.Add(-window),
+		To:   to,
+	})
+	if err != nil {
+		return BudgetReport{}, err
+	}
+	r := BudgetReport{
+		Window: window,
+		Target: target,
+		Runs:   len(results),
 	}
+	for _, res := range results {
+		switch {
+		case res.Degraded():
+			r.Degraded++
+			r.Successes++
+		case res.Passed():
+			r.Successes++
+		default:
+			r.Failures++
+		}
+	}
+	r.SuccessRatio, r.Remaining = 1, 1
+	if r.Runs > 0 {
+		failureRatio := float64(r.Failures) / float64(r.Runs)
+		r.SuccessRatio = 1 - failureRatio
+		r.Remaining = 1 - failureRatio/(1-target)
+	}
+	return r, nil
 }
 
-// Create creates a new check with the specified details. It returns the
-// check ID of the newly-created check, or an error.
-func (c *Client) Create(check Check) (string, error) {
-	data, err := json.Marshal(check)
+// Uptime returns the percentage of runs of the specified check which passed
+// over the window up to now, rounded to two decimal places (for example,
+// 99.95), for use in status badges and as a service level indicator. Degraded
+// runs count as passes. If the check has no runs in the window, its uptime is
+// 100.
+func (c *Client) Uptime(checkID string, window time.Duration) (float64, error) {
+	to := time.Now()
+	results, err := c.ListCheckResults(checkID, ResultsOptions{
+		From: to.Add(-window),
+		To:   to,
+	})
 	if err != nil {
-		return "", err
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 100, nil
+	}
+	passed := 0
+	for _, r := range results {
+		if r.Passed() {
+			passed++
+		}
 	}
-	status, res, err := c.MakeAPICall(http.MethodPost, "checks", data)
+	return math.Round(float64(passed)/float64(len(results))*10000) / 100, nil
+}
+
+// pingURL is the base URL to which heartbeat checks are pinged.
+const pingURL = "https://ping.checklyhq.com/"
+
+// HeartbeatPingURL returns the URL which the job monitored by the specified
+// heartbeat check should request (for example, with curl) each time it
+// succeeds. It returns an error if the check is not a heartbeat check.
+func (c *Client) HeartbeatPingURL(checkID string) (string, error) {
+	check, err := c.Get(checkID)
 	if err != nil {
 		return "", err
 	}
-	if status != http.StatusCreated {
-		return "", fmt.Errorf("unexpected response status %d: %q", status, res)
+	if check.Type != TypeHeartbeat {
+		return "", fmt.Errorf("check %s is a %s check, not a heartbeat check", checkID, check.Type)
 	}
-	var result Check
-	if err = json.NewDecoder(strings.NewReader(res)).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding error for data %s: %v", res, err)
+	if check.Heartbeat == nil || check.Heartbeat.PingToken == "" {
+		return "", fmt.Errorf("heartbeat check %s has no ping token", checkID)
 	}
-	return result.ID, nil
+	return pingURL + check.Heartbeat.PingToken, nil
 }
 
-// Update updates an existing check with the specified details. It returns a
-// non-nil error if the request failed.
-func (c *Client) Update(ID string, check Check) error {
-	data, err := json.Marshal(check)
+// GetCheckStatus returns the current status of the specified check. If the
+// check has no status yet, it returns ErrNotFound.
+func (c *Client) GetCheckStatus(checkID string) (CheckStatus, error) {
+	return get[CheckStatus](c, "check-statuses/"+checkID)
+}
+
+// AllCheckStatuses returns the current status of every check in the account,
+// or an error.
+func (c *Client) AllCheckStatuses() ([]CheckStatus, error) {
+	return get[[]CheckStatus](c, "check-statuses")
+}
+
+// FailingChecks returns the status of each check whose most recent run had
+// failed assertions or errors, or an error. Degraded checks are still
+// passing, so they are not included; use DegradedChecks to find those.
+func (c *Client) FailingChecks() ([]CheckStatus, error) {
+	return c.filterCheckStatuses(func(s CheckStatus) bool {
+		return s.HasFailures || s.HasErrors
+	})
+}
+
+// DegradedChecks returns the status of each check which is passing, but
+// whose most recent run was degraded, or an error.
+func (c *Client) DegradedChecks() ([]CheckStatus, error) {
+	return c.filterCheckStatuses(CheckStatus.Degraded)
+}
+
+// filterCheckStatuses returns the statuses from AllCheckStatuses for which
+// keep returns true.
+func (c *Client) filterCheckStatuses(keep func(CheckStatus) bool) ([]CheckStatus, error) {
+	statuses, err := c.AllCheckStatuses()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result := []CheckStatus{}
+	for _, s := range statuses {
+		if keep(s) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// triggerSkew is how far the local clock is allowed to differ from the API's
+// when matching the result of a triggered run, if the API doesn't say when
+// the trigger was called.
+const triggerSkew = time.Minute
+
+// defaultPollInterval is how often to poll the API when waiting for
+// something to happen, unless the client's pollInterval is set.
+const defaultPollInterval = 10 * time.Second
+
+// WaitHealthy waits until the specified check has a passing run, polling its
+// status until it passes, the timeout expires, or ctx is cancelled. This can
+// be used, for example, to hold a deployment until a newly-created check
+// confirms that the service is up. If the check does not pass in time, the
+// returned error includes its last known status.
+func (c *Client) WaitHealthy(ctx context.Context, checkID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	c = c.withContext(ctx)
+	interval := c.pollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	last := "unknown"
+	for {
+		s, err := c.GetCheckStatus(checkID)
+		switch {
+		case err == nil:
+			if s.Passing() {
+				return nil
+			}
+			last = s.String()
+		case ctx.Err() == nil && !errors.Is(err, ErrNotFound):
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for check %s to pass (last status: %s): %w", checkID, last, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// trigger returns the trigger for the specified check or check group (given
+// as, for example, "checks/<ID>"), creating it if it does not already exist.
+func (c *Client) trigger(target string) (Trigger, error) {
+	URL := "triggers/" + target
+	status, res, err := c.MakeAPICall(http.MethodGet, URL, nil)
+	if err != nil {
+		return Trigger{}, err
+	}
+	if status == http.StatusNotFound {
+		status, res, err = c.MakeAPICall(http.MethodPost, URL, nil)
+		if err != nil {
+			return Trigger{}, err
+		}
+		if status != http.StatusCreated {
+			return Trigger{}, newAPIError(status, res)
+		}
+	} else if status != http.StatusOK {
+		return Trigger{}, newAPIError(status, res)
+	}
+	return decode[Trigger](res)
+}
+
+// RunGroup runs all the activated checks in the specified group immediately,
+// using the group's trigger (which is created if necessary), waits for each
+// run to finish, as RunCheck does, and returns the result of each check. If
+// any check failed, it returns the results together with a non-nil error
+// naming the failed checks, so that the caller (for example, a CI pipeline)
+// can treat the run as a failure.
+func (c *Client) RunGroup(ctx context.Context, groupID int64) ([]CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("running group %d: %w", groupID, err)
 	}
-	status, res, err := c.MakeAPICall(http.MethodPut, "checks/"+ID, data)
+	c = c.withContext(ctx)
+	checks, err := c.ListChecksByGroup(groupID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	start, err := c.runTrigger(fmt.Sprintf("check-groups/%d", groupID))
+	if err != nil {
+		return nil, err
+	}
+	results := []CheckResult{}
+	var failed []string
+	for _, check := range checks {
+		if !check.Activated {
+			continue
+		}
+		r, err := c.waitForResult(ctx, check.ID, start)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, r)
+		if !r.Passed() {
+			failed = append(failed, fmt.Sprintf("%q", r.Name))
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%d of %d checks failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return results, nil
+}
+
+// Account returns the details of the account the client's API key belongs
+// to, which is useful for confirming which account you are operating on. If
+// the API key is not valid, it returns ErrUnauthorized.
+func (c *Client) Account() (Account, error) {
+	return get[Account](c, "accounts/me")
+}
+
+// APISchema returns the OpenAPI specification which Checkly publishes for its
+// API, as raw JSON, for use by code generation and validation tools. The
+// schema is not versioned like the API endpoints, so it is fetched from the
+// root of the API URL.
+func (c *Client) APISchema() ([]byte, error) {
+	status, res, err := c.do(http.MethodGet, c.URL+"/openapi.json", "application/json", nil)
+	if err != nil {
+		return nil, err
 	}
 	if status != http.StatusOK {
-		return fmt.Errorf("unexpected response status %d: %q", status, res)
+		return nil, newAPIError(status, res)
 	}
-	var result Check
-	if err = json.NewDecoder(strings.NewReader(res)).Decode(&result); err != nil {
-		return fmt.Errorf("decoding error for data %s: %v", res, err)
+	return []byte(res), nil
+}
+
+// CreateDashboard creates a new dashboard with the specified details. It
+// returns the ID of the newly-created dashboard, or an error.
+func (c *Client) CreateDashboard(dashboard Dashboard) (string, error) {
+	result, err := post[Dashboard](c, "dashboards", dashboard)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	return result.ID, nil
 }
 
-// Delete deletes the check with the specified ID. It returns a non-nil
-// error if the request failed.
-func (c *Client) Delete(ID string) error {
-	status, res, err := c.MakeAPICall(http.MethodDelete, "checks/"+ID, nil)
+// GetDashboard takes the ID of an existing dashboard, and returns the
+// dashboard parameters, or an error.
+func (c *Client) GetDashboard(ID string) (Dashboard, error) {
+	return get[Dashboard](c, "dashboards/"+ID)
+}
+
+// UpdateDashboard updates an existing dashboard with the specified details.
+// It returns a non-nil error if the request failed.
+func (c *Client) UpdateDashboard(ID string, dashboard Dashboard) error {
+	_, err := put[Dashboard](c, "dashboards/"+ID, dashboard)
+	return err
+}
+
+// DeleteDashboard deletes the dashboard with the specified ID. It returns a
+// non-nil error if the request failed.
+func (c *Client) DeleteDashboard(ID string) error {
+	status, res, err := c.MakeAPICall(http.MethodDelete, "dashboards/"+ID, nil)
 	if err != nil {
 		return err
 	}
 	if status != http.StatusNoContent {
-		return fmt.Errorf("unexpected response status %d: %q", status, res)
+		return newAPIError(status, res)
 	}
 	return nil
 }
 
-// Get takes the ID of an existing check, and returns the check parameters, or
-// an error.
-func (c *Client) Get(ID string) (Check, error) {
-	status, res, err := c.MakeAPICall(http.MethodGet, "checks/"+ID, nil)
+// RunCheck runs the specified check immediately, using the check's trigger
+// (which is created if necessary), waits for the run to finish, and returns
+// its result. It polls for the result until it appears or ctx is cancelled, so
+// callers should set a deadline on ctx suitable for the check's run time.
+// The result is the first to start after the trigger was called, according to
+// the API's clock, so it can be from a scheduled run which happened to start
+// at the same time.
+//
+// If ctx is already cancelled, the check is not run. Cancelling ctx once the
+// run has started stops RunCheck waiting for it, including abandoning any API
+// call in progress, but the API has no way to abort a run, so the run itself
+// carries on and its result is recorded as usual.
+func (c *Client) RunCheck(ctx context.Context, checkID string) (CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return CheckResult{}, fmt.Errorf("running check %s: %w", checkID, err)
+	}
+	c = c.withContext(ctx)
+	start, err := c.runTrigger("checks/" + checkID)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	return c.waitForResult(ctx, checkID, start)
+}
+
+// runTrigger calls the trigger for the specified check or check group (given
+// as, for example, "checks/<ID>"), creating it if necessary. The API starts
+// the triggered runs in the background, so it returns as soon as they are
+// queued, with the earliest time at which their results may have started.
+//
+// This time comes from the trigger's CalledAt, which is set by the API's
+// clock, so that a skewed local clock doesn't make the results appear to
+// start before the trigger was called. If the API doesn't report the call,
+// the local time less triggerSkew is used instead.
+func (c *Client) runTrigger(target string) (time.Time, error) {
+	trigger, err := c.trigger(target)
 	if err != nil {
-		return Check{}, err
+		return time.Time{}, err
+	}
+	start := time.Now().Add(-triggerSkew)
+	URL := fmt.Sprintf("%s/%s/trigger/%s", c.URL, target, trigger.Token)
+	status, res, err := c.do(http.MethodGet, URL, "application/json", nil)
+	if err != nil {
+		return time.Time{}, err
 	}
 	if status != http.StatusOK {
-		return Check{}, fmt.Errorf("unexpected response status %d: %q", status, res)
+		return time.Time{}, newAPIError(status, res)
+	}
+	called, err := c.trigger(target)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if called.CalledAt.After(trigger.CalledAt.Time) {
+		start = called.CalledAt.Time
+	}
+	// Results only have one-second precision in queries.
+	return start.Truncate(time.Second), nil
+}
+
+// waitForResult polls the results of the specified check until one started
+// at or after start appears, and returns the earliest such result, since any
+// later ones may be from scheduled runs. It gives up when ctx is cancelled.
+func (c *Client) waitForResult(ctx context.Context, checkID string, start time.Time) (CheckResult, error) {
+	interval := c.pollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return CheckResult{}, fmt.Errorf("waiting for result of check %s: %w", checkID, err)
+		}
+		results, err := c.ListCheckResults(checkID, ResultsOptions{From: start})
+		if err != nil {
+			return CheckResult{}, err
+		}
+		var first *CheckResult
+		for i, r := range results {
+			if !r.StartedAt.Before(start) && (first == nil || r.StartedAt.Before(first.StartedAt.Time)) {
+				first = &results[i]
+			}
+		}
+		if first != nil {
+			return *first, nil
+		}
+		select {
+		case <-ctx.Done():
+			return CheckResult{}, fmt.Errorf("waiting for result of check %s: %w", checkID, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// GetCheckResult returns the full details of the specified result of the
+// specified check, including, for browser checks, the BrowserCheckResult.
+func (c *Client) GetCheckResult(checkID, resultID string) (CheckResult, error) {
+	return get[CheckResult](c, "check-results/"+checkID+"/"+resultID)
+}
+
+// RunBrowserCheck runs the specified browser check immediately, as RunCheck
+// does, and returns its result together with the URLs of any screenshots
+// taken and the log messages written during the run. Browser checks can take
+// a minute or more to run, so set a suitable deadline on ctx.
+func (c *Client) RunBrowserCheck(ctx context.Context, checkID string) (BrowserRunResult, error) {
+	c = c.withContext(ctx)
+	r, err := c.RunCheck(ctx, checkID)
+	if err != nil {
+		return BrowserRunResult{}, err
+	}
+	r, err = c.GetCheckResult(checkID, r.ID)
+	if err != nil {
+		return BrowserRunResult{}, err
+	}
+	if r.BrowserCheckResult == nil {
+		return BrowserRunResult{}, fmt.Errorf("check %s result %s has no browser check data", checkID, r.ID)
+	}
+	run := BrowserRunResult{
+		Result: r,
+		Logs:   r.BrowserCheckResult.JobLog,
+	}
+	for _, asset := range r.BrowserCheckResult.JobAssets {
+		switch strings.ToLower(path.Ext(asset)) {
+		case ".png", ".jpg", ".jpeg":
+			run.Screenshots = append(run.Screenshots, asset)
+		}
+	}
+	return run, nil
+}
+
+// ExportAll returns the complete configuration of the account: its checks,
+// groups, snippets, alert channels, and environment variables. The result can
+// be saved as a backup, or passed to ImportAll to recreate the configuration
+// in another account.
+func (c *Client) ExportAll() (AccountBundle, error) {
+	var bundle AccountBundle
+	var err error
+	if bundle.Checks, err = c.ListChecks(); err != nil {
+		return AccountBundle{}, err
+	}
+	if bundle.Groups, err = c.ListGroups(); err != nil {
+		return AccountBundle{}, err
+	}
+	if bundle.Snippets, err = c.ListSnippets(); err != nil {
+		return AccountBundle{}, err
 	}
-	check := Check{}
-	if err = json.NewDecoder(strings.NewReader(res)).Decode(&check); err != nil {
-		return Check{}, fmt.Errorf("decoding error for data %s: %v", res, err)
+	if bundle.AlertChannels, err = c.ListAlertChannels(); err != nil {
+		return AccountBundle{}, err
 	}
-	return check, nil
+	if bundle.Variables, err = c.ListVariables(); err != nil {
+		return AccountBundle{}, err
+	}
+	return bundle, nil
+}
+
+// Plan compares the desired checks with the checks in the account, matching
+// them by name, and returns the actions needed to make the account match,
+// without changing anything: PlanCreate for desired checks which don't
+// exist, PlanUpdate (with the changes, from Check.Diff) for those which
+// differ, and PlanNoOp for those which already match. Existing checks not
+// among the desired checks are listed as PlanDelete. It returns an error if
+// two desired checks have the same name, since they can't both be matched.
+//
+// Only the fields the desired check sets are compared: a field left at its
+// zero value (other than a boolean) is taken to be unset, so defaults filled
+// in by the API don't count as changes. To clear a field, update the check
+// directly. Since the real values of locked and secret environment variables
+// can't be read, a change to one of them is not detected.
+func (c *Client) Plan(desired []Check) (PlanResult, error) {
+	existing, err := c.ListChecks()
+	if err != nil {
+		return PlanResult{}, err
+	}
+	byName := map[string]Check{}
+	for _, check := range existing {
+		if _, ok := byName[check.Name]; !ok {
+			byName[check.Name] = check
+		}
+	}
+	plan := PlanResult{}
+	wanted := map[string]bool{}
+	for _, check := range desired {
+		if wanted[check.Name] {
+			return PlanResult{}, fmt.Errorf("duplicate desired check name %q", check.Name)
+		}
+		wanted[check.Name] = true
+		remote, ok := byName[check.Name]
+		if !ok {
+			plan.Items = append(plan.Items, PlanItem{Action: PlanCreate, Check: check})
+			continue
+		}
+		item := PlanItem{Action: PlanNoOp, ID: remote.ID, Check: check}
+		if item.Changes = remote.planBase(check).Diff(check); item.Changes != nil {
+			item.Action = PlanUpdate
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	for _, check := range existing {
+		if !wanted[check.Name] {
+			plan.Items = append(plan.Items, PlanItem{Action: PlanDelete, ID: check.ID, Check: check})
+		}
+	}
+	return plan, nil
+}
+
+// applyConcurrency is the number of API calls Apply makes at once.
+const applyConcurrency = 4
+
+// Apply carries out the actions in plan, as computed by Plan: it creates,
+// updates, and (if plan.PruneOrphans is set) deletes checks, making up to
+// applyConcurrency API calls at once. To update a check, it fetches the
+// current check and lays over it only the fields the desired check sets, as
+// Plan compares them, so that fields left unset keep their current values. A failed action doesn't stop the
+// others: Apply carries on, records the outcome of every item in the result,
+// and then returns an error listing the failures, if there were any.
+func (c *Client) Apply(plan PlanResult) (ApplyResult, error) {
+	result := ApplyResult{Outcomes: make([]ApplyOutcome, len(plan.Items))}
+	sem := make(chan struct{}, applyConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range plan.Items {
+		result.Outcomes[i] = ApplyOutcome{Item: item, ID: item.ID}
+		var action func() (string, error)
+		switch item.Action {
+		case PlanCreate:
+			check := item.Check
+			action = func() (string, error) { return c.Create(check) }
+		case PlanUpdate:
+			ID, check := item.ID, item.Check
+			action = func() (string, error) {
+				remote, err := c.Get(ID)
+				if err != nil {
+					return ID, err
+				}
+				return ID, c.Update(ID, remote.withSetFrom(check))
+			}
+		case PlanDelete:
+			if !plan.PruneOrphans {
+				continue
+			}
+			ID := item.ID
+			action = func() (string, error) { return ID, c.Delete(ID) }
+		case PlanNoOp:
+			continue
+		default:
+			result.Outcomes[i].Err = fmt.Errorf("unknown plan action %q", item.Action)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(o *ApplyOutcome) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ID, err := action()
+			if err != nil {
+				o.Err = err
+				return
+			}
+			o.ID = ID
+			o.Applied = true
+		}(&result.Outcomes[i])
+	}
+	wg.Wait()
+	var failures []string
+	for _, o := range result.Outcomes {
+		if o.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s %q: %v", o.Item.Action, o.Item.Check.Name, o.Err))
+		}
+	}
+	if len(failures) > 0 {
+		return result, fmt.Errorf("failed to apply %d changes: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return result, nil
+}
+
+// DiffAccounts compares the checks in the accounts of clients a and b (for
+// example, staging and production), matching checks by name, and returns
+// their differences, using Check.Diff. Since references to groups, snippets,
+// and alert channels use IDs specific to each account, those fields are not
+// compared. If an account has more than one check with the same name, only
+// the first is compared.
+func DiffAccounts(a, b *Client) (AccountDiff, error) {
+	checksA, err := a.ListChecks()
+	if err != nil {
+		return AccountDiff{}, err
+	}
+	checksB, err := b.ListChecks()
+	if err != nil {
+		return AccountDiff{}, err
+	}
+	byName := map[string]Check{}
+	for _, check := range checksB {
+		if _, ok := byName[check.Name]; !ok {
+			byName[check.Name] = check
+		}
+	}
+	diff := AccountDiff{Changed: map[string][]FieldDiff{}}
+	seen := map[string]bool{}
+	for _, check := range checksA {
+		if seen[check.Name] {
+			continue
+		}
+		seen[check.Name] = true
+		other, ok := byName[check.Name]
+		if !ok {
+			diff.OnlyInA = append(diff.OnlyInA, check.Name)
+			continue
+		}
+		var changes []FieldDiff
+		for _, d := range check.Diff(other) {
+			if !accountSpecificFields[d.Field] {
+				changes = append(changes, d)
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed[check.Name] = changes
+		}
+	}
+	for _, check := range checksB {
+		if !seen[check.Name] {
+			seen[check.Name] = true
+			diff.OnlyInB = append(diff.OnlyInB, check.Name)
+		}
+	}
+	return diff, nil
+}
+
+// accountSpecificFields lists the check fields which refer to other resources
+// by account-specific IDs, and so are expected to differ between accounts.
+var accountSpecificFields = map[string]bool{
+	"GroupID":                   true,
+	"SetupSnippetID":            true,
+	"TearDownSnippetID":         true,
+	"AlertChannelSubscriptions": true,
+}
+
+// ImportAll creates all the resources in bundle (as returned by ExportAll).
+// Since the API assigns new IDs to created resources, any references between
+// them (a check's group, snippets, and alert channel subscriptions, and a
+// group's snippets and alert channel subscriptions) are updated to use the
+// new IDs. It stops and returns an error at the first resource which fails to
+// import, leaving any resources already created in place.
+//
+// The real values of locked and secret environment variables can't be
+// exported, so any variables with masked values (see
+// EnvironmentVariable.Masked) are left out of the import, and must be set
+// afterwards.
+func (c *Client) ImportAll(bundle AccountBundle) error {
+	snippetIDs := map[int64]int64{}
+	for _, s := range bundle.Snippets {
+		oldID := s.ID
+		s.ID = 0
+		ID, err := c.CreateSnippet(s)
+		if err != nil {
+			return fmt.Errorf("importing snippet %q: %v", s.Name, err)
+		}
+		snippetIDs[oldID] = ID
+	}
+	channelIDs := map[int64]int64{}
+	for _, ch := range bundle.AlertChannels {
+		oldID := ch.ID
+		ch.ID = 0
+		ID, err := c.CreateAlertChannel(ch)
+		if err != nil {
+			return fmt.Errorf("importing alert channel %d: %v", oldID, err)
+		}
+		channelIDs[oldID] = ID
+	}
+	groupIDs := map[int64]int64{}
+	for _, g := range bundle.Groups {
+		oldID := g.ID
+		g.ID = 0
+		g.SetupSnippetID = remapID(snippetIDs, g.SetupSnippetID)
+		g.TearDownSnippetID = remapID(snippetIDs, g.TearDownSnippetID)
+		g.AlertChannelSubscriptions = remapSubscriptions(channelIDs, g.AlertChannelSubscriptions)
+		g.EnvironmentVariables = unmasked(g.EnvironmentVariables)
+		if d := g.BrowserCheckDefaults; d != nil {
+			defaults := *d
+			defaults.EnvironmentVariables = unmasked(d.EnvironmentVariables)
+			g.BrowserCheckDefaults = &defaults
+		}
+		ID, err := c.CreateGroup(g)
+		if err != nil {
+			return fmt.Errorf("importing group %q: %v", g.Name, err)
+		}
+		groupIDs[oldID] = ID
+	}
+	for _, check := range bundle.Checks {
+		check.ID = ""
+		check.GroupID = remapID(groupIDs, check.GroupID)
+		check.SetupSnippetID = remapID(snippetIDs, check.SetupSnippetID)
+		check.TearDownSnippetID = remapID(snippetIDs, check.TearDownSnippetID)
+		check.AlertChannelSubscriptions = remapSubscriptions(channelIDs, check.AlertChannelSubscriptions)
+		check.EnvironmentVariables = unmasked(check.EnvironmentVariables)
+		if _, err := c.Create(check); err != nil {
+			return fmt.Errorf("importing check %q: %v", check.Name, err)
+		}
+	}
+	for _, v := range bundle.Variables {
+		if v.Masked() {
+			continue
+		}
+		if err := c.CreateVariable(v); err != nil {
+			return fmt.Errorf("importing variable %q: %v", v.Key, err)
+		}
+	}
+	return nil
+}
+
+// remapSubscriptions returns a copy of subs referring to the new alert
+// channel IDs in channelIDs, without the old subscription, check, and group
+// IDs.
+func remapSubscriptions(channelIDs map[int64]int64, subs []Subscription) []Subscription {
+	remapped := make([]Subscription, len(subs))
+	for i, s := range subs {
+		remapped[i] = Subscription{
+			AlertChannelID: remapID(channelIDs, s.AlertChannelID),
+			Activated:      s.Activated,
+		}
+	}
+	return remapped
+}
+
+// remapID returns the new ID corresponding to oldID in IDs, or oldID itself if
+// it has no mapping (for example, if it is zero, meaning no reference).
+func remapID(IDs map[int64]int64, oldID int64) int64 {
+	if newID, ok := IDs[oldID]; ok {
+		return newID
+	}
+	return oldID
+}
+
+// call calls the Checkly API with the specified method and URL, sending body
+// (unless it is nil) as JSON. If the response status is want, it decodes the
+// response into a T and returns it; otherwise, it returns an APIError.
+func call[T any](c *Client, method, URL string, body interface{}, want int) (T, error) {
+	var zero T
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return zero, err
+		}
+	}
+	status, res, err := c.MakeAPICall(method, URL, data)
+	if err != nil {
+		return zero, err
+	}
+	if status != want {
+		return zero, newAPIError(status, res)
+	}
+	return decode[T](res)
+}
+
+// get fetches the resource at URL and decodes it into a T, expecting status
+// 200 OK.
+func get[T any](c *Client, URL string) (T, error) {
+	return call[T](c, http.MethodGet, URL, nil, http.StatusOK)
+}
+
+// post creates a resource at URL from body, and decodes the created resource
+// into a T, expecting status 201 Created.
+func post[T any](c *Client, URL string, body interface{}) (T, error) {
+	return call[T](c, http.MethodPost, URL, body, http.StatusCreated)
+}
+
+// put updates the resource at URL from body, and decodes the updated resource
+// into a T, expecting status 200 OK.
+func put[T any](c *Client, URL string, body interface{}) (T, error) {
+	return call[T](c, http.MethodPut, URL, body, http.StatusOK)
+}
+
+// decode decodes the JSON response res into a T.
+func decode[T any](res string) (T, error) {
+	var result T
+	if err := json.NewDecoder(strings.NewReader(res)).Decode(&result); err != nil {
+		var zero T
+		return zero, fmt.Errorf("decoding error for data %s: %v", res, err)
+	}
+	return result, nil
 }
 
-// MakeAPICall calls the Checkly API with the specified URL and data, and
+// MakeAPICall calls the Checkly API with the specified URL and JSON data, and
 // returns the HTTP status code and string data of the response.
 func (c *Client) MakeAPICall(method string, URL string, data []byte) (statusCode int, response string, err error) {
-	requestURL := c.URL + "/v1/" + URL
-	req, err := http.NewRequest(method, requestURL, bytes.NewBuffer(data))
+	return c.MakeAPICallWithContentType(method, URL, "application/json", data)
+}
+
+// MakeAPICallWithContentType is like MakeAPICall, but sends data with the
+// specified content type (for example, "application/x-www-form-urlencoded")
+// instead of JSON.
+func (c *Client) MakeAPICallWithContentType(method string, URL string, contentType string, data []byte) (statusCode int, response string, err error) {
+	return c.do(method, c.URL+"/"+c.apiVersion()+"/"+URL, contentType, data)
+}
+
+// withContext returns a copy of the client whose API calls are made with ctx,
+// so that they are abandoned if ctx is cancelled.
+func (c *Client) withContext(ctx context.Context) *Client {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// WithAPIVersion returns a copy of the client which makes its API calls to the
+// specified version of the API (for example, "v2"), for resources which are
+// only available in a newer version.
+func (c *Client) WithAPIVersion(version string) Client {
+	client := *c
+	client.APIVersion = version
+	return client
+}
+
+// apiVersion returns the API version the client should use.
+func (c *Client) apiVersion() string {
+	if c.APIVersion == "" {
+		return DefaultAPIVersion
+	}
+	return c.APIVersion
+}
+
+// do makes an authenticated request to the specified absolute URL, sending
+// data with the specified content type, and returns the HTTP status code and
+// string data of the response.
+func (c *Client) do(method string, requestURL string, contentType string, data []byte) (statusCode int, response string, err error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewBuffer(data))
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to create HTTP request: %v", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+c.apiKey)
-	req.Header.Add("content-type", "application/json")
-	if c.Debug != nil {
-		requestDump, err := httputil.DumpRequestOut(req, true)
+	req.Header.Add("content-type", contentType)
+	if c.AccountID != "" {
+		req.Header.Set(AccountHeader, c.AccountID)
+	}
+	if c.Debug != nil && !c.DebugJSON {
+		requestDump, err := httputil.DumpRequestOut(req, !c.DebugIndent)
 		if err != nil {
 			return 0, "", fmt.Errorf("error dumping HTTP request: %v", err)
 		}
-		fmt.Fprintln(c.Debug, string(requestDump))
-		fmt.Fprintln(c.Debug)
+		if c.DebugIndent {
+			requestDump = append(requestDump, indentJSON(data)...)
+		}
+		if !c.DebugShowAuth {
+			requestDump = redactAuthorization(requestDump)
+		}
+		fmt.Fprintf(c.Debug, "%s\n\n", redactTriggerTokens(redactPasswords(requestDump)))
 	}
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return 0, "", fmt.Errorf("HTTP request failed: %v", err)
+		if c.Debug != nil && c.DebugJSON {
+			c.logJSON(req, 0, time.Since(start), err)
+		}
+		if ctx.Err() != nil {
+			return 0, "", fmt.Errorf("HTTP request failed: %w", ctx.Err())
+		}
+		return 0, "", fmt.Errorf("HTTP request failed: %s", redactTriggerTokens([]byte(err.Error())))
 	}
 	defer resp.Body.Close()
 	if c.Debug != nil {
-		c.dumpResponse(resp)
+		if c.DebugJSON {
+			c.logJSON(req, resp.StatusCode, time.Since(start), nil)
+		} else if !c.DebugIndent {
+			c.dumpResponse(resp)
+		}
 	}
 	res, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return resp.StatusCode, "", err
 	}
+	if c.Debug != nil && !c.DebugJSON && c.DebugIndent {
+		c.dumpResponseIndented(resp, res)
+	}
 	return resp.StatusCode, string(res), nil
 }
 
+// AccountTransport is an http.RoundTripper which adds the X-Checkly-Account
+// header, set to AccountID, to each request which doesn't already have it,
+// and then passes it to Base (or http.DefaultTransport, if Base is nil). It
+// can be wrapped around any other transport, so that every request made by an
+// HTTP client goes to the same account. Client does this itself if its
+// AccountID field is set.
+type AccountTransport struct {
+	AccountID string
+	Base      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper. Since a RoundTripper must not modify
+// the request, it adds the header to a copy.
+func (t AccountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.AccountID == "" || req.Header.Get(AccountHeader) != "" {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(AccountHeader, t.AccountID)
+	return base.RoundTrip(req)
+}
+
 // dumpResponse writes the raw response data to the debug output, if set, or
 // standard error otherwise.
 func (c *Client) dumpResponse(resp *http.Response) {
 	// ignore errors dumping response - no recovery from this
 	responseDump, _ := httputil.DumpResponse(resp, true)
-	fmt.Fprintln(c.Debug, string(responseDump))
-	fmt.Fprintln(c.Debug)
+	fmt.Fprintf(c.Debug, "%s\n\n", redactPasswords(responseDump))
+}
+
+// dumpResponseIndented writes the response headers to the debug output,
+// followed by body, indented if it is JSON.
+func (c *Client) dumpResponseIndented(resp *http.Response, body []byte) {
+	// ignore errors dumping response - no recovery from this
+	responseDump, _ := httputil.DumpResponse(resp, false)
+	responseDump = append(responseDump, indentJSON(body)...)
+	fmt.Fprintf(c.Debug, "%s\n\n", redactPasswords(responseDump))
+}
+
+// indentJSON returns data indented for readability, or unchanged if it is not
+// valid JSON.
+func indentJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// passwordRE matches the value of a "password" or "token" field in JSON data.
+var passwordRE = regexp.MustCompile(`("(?:password|token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactPasswords returns dump with the values of any JSON "password" fields
+// (such as dashboard passwords and basic auth credentials) and "token" fields
+// (such as trigger tokens) replaced, so that they don't appear in debug
+// output.
+func redactPasswords(dump []byte) []byte {
+	return passwordRE.ReplaceAll(dump, []byte(`$1"[REDACTED]"`))
+}
+
+// triggerTokenRE matches the token in the path of a check or group trigger
+// URL, which is enough on its own to run the check.
+var triggerTokenRE = regexp.MustCompile(`(/trigger/)[^/?#\s"]+`)
+
+// redactTriggerTokens returns data with the token in any trigger URL
+// replaced, so that it doesn't appear in debug output or errors.
+func redactTriggerTokens(data []byte) []byte {
+	return triggerTokenRE.ReplaceAll(data, []byte(`${1}[REDACTED]`))
+}
+
+// authorizationRE matches the credentials in an Authorization header line of
+// an HTTP request dump.
+var authorizationRE = regexp.MustCompile(`(?im)^(Authorization:\s*(?:Bearer\s+)?)\S.*?(\r?)$`)
+
+// redactAuthorization returns dump with the credentials in any Authorization
+// header replaced, so that the API key doesn't appear in debug output.
+func redactAuthorization(dump []byte) []byte {
+	return authorizationRE.ReplaceAll(dump, []byte(`${1}[REDACTED]${2}`))
+}
+
+// debugEntry is the structured log record written to the debug output for
+// each API call when DebugJSON is set.
+type debugEntry struct {
+	Method   string  `json:"method"`
+	URL      string  `json:"url"`
+	Status   int     `json:"status"`
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// logJSON writes a single-line JSON record of an API call to the debug
+// output. The duration is given in seconds.
+func (c *Client) logJSON(req *http.Request, status int, elapsed time.Duration, err error) {
+	entry := debugEntry{
+		Method:   req.Method,
+		URL:      string(redactTriggerTokens([]byte(req.URL.String()))),
+		Status:   status,
+		Duration: elapsed.Seconds(),
+	}
+	if err != nil {
+		entry.Error = string(redactTriggerTokens([]byte(err.Error())))
+	}
+	// ignore encoding errors - no recovery from this
+	line, _ := json.Marshal(entry)
+	fmt.Fprintf(c.Debug, "%s\n", line)
 }
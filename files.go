@@ -0,0 +1,87 @@
+package checkly
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// LoadChecksDir reads every file with a .json extension in the directory at
+// path, and returns the checks they contain, in order of filename. Each file
+// must contain a single check, as written by SaveChecksDir.
+func LoadChecksDir(path string) ([]Check, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	checks := []Check{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(path, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var check Check
+		if err := json.Unmarshal(data, &check); err != nil {
+			return nil, fmt.Errorf("decoding %s: %v", f.Name(), err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// SaveChecksDir writes each of checks to its own JSON file in the directory at
+// path, which must already exist. Each file is named after the check's ID or,
+// if it has none, a slug of its name (for example, "my-api-check.json"). If
+// two checks would have the same filename, the later one in checks gets a
+// numeric suffix ("my-api-check-2.json"), so the same checks always produce
+// the same files. Existing files with the same names are overwritten.
+func SaveChecksDir(path string, checks []Check) error {
+	used := map[string]bool{}
+	for _, check := range checks {
+		base := check.ID
+		if base == "" {
+			base = slugify(check.Name)
+		}
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		data, err := json.MarshalIndent(check, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(path, name+".json"), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slugify returns a lower-case version of s suitable for use as a filename,
+// with each run of characters other than letters and digits replaced by a
+// hyphen.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "check"
+	}
+	return slug
+}
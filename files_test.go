@@ -0,0 +1,68 @@
+package checkly
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveLoadChecksDir(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "checkly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	checks := []Check{
+		NewUptimeCheck("My API Check", "https://example.com"),
+		NewUptimeCheck("my api check!", "https://example.com/2"),
+		NewUptimeCheck("Other", "https://example.com/3"),
+	}
+	checks[2].ID = "73d29e72-6540-4bb5-967e-e07fa2c9465e"
+	if err := SaveChecksDir(dir, checks); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	wantFiles := []string{
+		"73d29e72-6540-4bb5-967e-e07fa2c9465e.json",
+		"my-api-check-2.json",
+		"my-api-check.json",
+	}
+	if !cmp.Equal(wantFiles, files) {
+		t.Error(cmp.Diff(wantFiles, files))
+	}
+	loaded, err := LoadChecksDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("want 3 checks, got %d", len(loaded))
+	}
+	if loaded[1].Request.URL != "https://example.com/2" {
+		t.Errorf("want my-api-check-2.json to hold the second check, got URL %q", loaded[1].Request.URL)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]string{
+		"My API Check":     "my-api-check",
+		"  leading--dash ": "leading-dash",
+		"Café 2.0!":        "café-2-0",
+		"!!!":              "check",
+	}
+	for name, want := range tcs {
+		if got := slugify(name); want != got {
+			t.Errorf("%q: want %q, got %q", name, want, got)
+		}
+	}
+}
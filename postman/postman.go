@@ -0,0 +1,227 @@
+// Package postman converts Postman collections into Checkly API checks.
+package postman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bitfield/checkly"
+)
+
+// Options controls the checks created by ChecksFromPostman. If Frequency or
+// Locations are zero, the defaults from checkly.NewUptimeCheck are used. Tags
+// are added to every check.
+type Options struct {
+	Frequency int
+	Locations []string
+	Tags      []string
+}
+
+// collection represents the parts of a Postman collection (schema v2.0 or
+// v2.1) which are relevant to checks.
+type collection struct {
+	Item []item `json:"item"`
+	Auth *auth  `json:"auth"`
+}
+
+// item is either a request or a folder containing further items.
+type item struct {
+	Name    string   `json:"name"`
+	Request *request `json:"request"`
+	Item    []item   `json:"item"`
+	Auth    *auth    `json:"auth"`
+}
+
+type request struct {
+	Method string     `json:"method"`
+	Header []keyValue `json:"header"`
+	URL    postmanURL `json:"url"`
+	Body   *body      `json:"body"`
+	Auth   *auth      `json:"auth"`
+}
+
+type keyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// postmanURL is a request URL, which Postman stores either as a string or as
+// an object with the string in its "raw" field.
+type postmanURL string
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = postmanURL(raw)
+		return nil
+	}
+	var obj struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*u = postmanURL(obj.Raw)
+	return nil
+}
+
+type body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	URLEncoded []keyValue `json:"urlencoded"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// auth represents Postman's authentication settings, where the parameters for
+// each type are a list of key-value pairs.
+type auth struct {
+	Type   string     `json:"type"`
+	Basic  []keyValue `json:"basic"`
+	Bearer []keyValue `json:"bearer"`
+}
+
+// param returns the value of the parameter with the specified key, or the
+// empty string if there is none.
+func param(params []keyValue, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// ChecksFromPostman returns an API check for each request in the Postman
+// collection, including those in folders, or an error. Each check is named
+// after its request, prefixed with the names of any enclosing folders, and
+// makes the request with the same method, URL, headers, body, and basic or
+// bearer authentication, asserting a 200 status. Postman variables such as
+// {{baseUrl}} are left as they are, since Checkly uses the same syntax for
+// environment variables. Request bodies sent as multipart form data, or
+// files, are not supported.
+func ChecksFromPostman(data []byte, opts Options) ([]checkly.Check, error) {
+	var c collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing Postman collection: %v", err)
+	}
+	if len(c.Item) == 0 {
+		return nil, errors.New("Postman collection contains no requests")
+	}
+	return convertItems(c.Item, "", c.Auth, opts)
+}
+
+func convertItems(items []item, prefix string, inherited *auth, opts Options) ([]checkly.Check, error) {
+	checks := []checkly.Check{}
+	for _, it := range items {
+		a := inherited
+		if it.Auth != nil {
+			a = it.Auth
+		}
+		name := prefix + it.Name
+		if it.Request == nil {
+			folder, err := convertItems(it.Item, name+" / ", a, opts)
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, folder...)
+			continue
+		}
+		if it.Request.Auth != nil {
+			a = it.Request.Auth
+		}
+		check, err := convertRequest(name, *it.Request, a, opts)
+		if err != nil {
+			return nil, fmt.Errorf("converting request %q: %v", name, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func convertRequest(name string, r request, a *auth, opts Options) (checkly.Check, error) {
+	check := checkly.NewUptimeCheck(name, string(r.URL))
+	if r.Method != "" {
+		check.Request.Method = strings.ToUpper(r.Method)
+	}
+	if opts.Frequency != 0 {
+		check.Frequency = opts.Frequency
+	}
+	if len(opts.Locations) > 0 {
+		check.Locations = append([]string(nil), opts.Locations...)
+	}
+	check.Tags = append(check.Tags, opts.Tags...)
+	for _, h := range r.Header {
+		if h.Disabled {
+			continue
+		}
+		check.Request.Headers = append(check.Request.Headers, checkly.KeyValue{
+			Key:   h.Key,
+			Value: h.Value,
+		})
+	}
+	if a != nil {
+		switch a.Type {
+		case "basic":
+			check.Request.BasicAuth = checkly.BasicAuth{
+				Username: param(a.Basic, "username"),
+				Password: param(a.Basic, "password"),
+			}
+		case "bearer":
+			check.Request.Headers = append(check.Request.Headers, checkly.KeyValue{
+				Key:   "Authorization",
+				Value: "Bearer " + param(a.Bearer, "token"),
+			})
+		case "noauth", "":
+		default:
+			return checkly.Check{}, fmt.Errorf("unsupported auth type %q", a.Type)
+		}
+	}
+	if r.Body == nil {
+		return check, nil
+	}
+	switch r.Body.Mode {
+	case "raw":
+		check.Request.Body = r.Body.Raw
+		check.Request.BodyType = checkly.BodyTypeRaw
+		if r.Body.Options.Raw.Language == "json" {
+			check.Request.BodyType = checkly.BodyTypeJSON
+		}
+	case "urlencoded":
+		values := map[string]string{}
+		for _, v := range r.Body.URLEncoded {
+			if !v.Disabled {
+				values[v.Key] = v.Value
+			}
+		}
+		check.Request.Body, check.Request.BodyType = checkly.FormBody(values)
+	case "graphql":
+		if r.Body.GraphQL == nil {
+			break
+		}
+		payload := map[string]interface{}{"query": r.Body.GraphQL.Query}
+		if r.Body.GraphQL.Variables != "" {
+			payload["variables"] = json.RawMessage(r.Body.GraphQL.Variables)
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return checkly.Check{}, fmt.Errorf("invalid GraphQL variables: %v", err)
+		}
+		check.Request.Body = string(data)
+		check.Request.BodyType = checkly.BodyTypeGraphQL
+	case "":
+	default:
+		return checkly.Check{}, fmt.Errorf("unsupported body mode %q", r.Body.Mode)
+	}
+	return check, nil
+}
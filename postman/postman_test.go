@@ -0,0 +1,75 @@
+package postman
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/bitfield/checkly"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChecksFromPostman(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile("testdata/collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks, err := ChecksFromPostman(data, Options{Frequency: 5, Tags: []string{"postman"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := checkly.NewUptimeCheck("Health", "https://api.example.com/health")
+	health.Frequency = 5
+	health.Tags = []string{"postman"}
+	create := checkly.NewUptimeCheck("Users / Create user", "{{baseUrl}}/users")
+	create.Frequency = 5
+	create.Tags = []string{"postman"}
+	create.Request.Method = http.MethodPost
+	create.Request.Headers = []checkly.KeyValue{
+		{Key: "Accept", Value: "application/json"},
+		{Key: "Authorization", Value: "Bearer {{API_TOKEN}}"},
+	}
+	create.Request.Body = `{"name":"jo"}`
+	create.Request.BodyType = checkly.BodyTypeJSON
+	login := checkly.NewUptimeCheck("Users / Login", "https://api.example.com/login")
+	login.Frequency = 5
+	login.Tags = []string{"postman"}
+	login.Request.Method = http.MethodPost
+	login.Request.BasicAuth = checkly.BasicAuth{Username: "jo", Password: "s3cret"}
+	login.Request.Body = "remember=yes+please"
+	login.Request.BodyType = checkly.BodyTypeForm
+	want := []checkly.Check{health, create, login}
+	if !cmp.Equal(want, checks) {
+		t.Error(cmp.Diff(want, checks))
+	}
+}
+
+func TestChecksFromPostmanUnsupportedBody(t *testing.T) {
+	t.Parallel()
+	data := []byte(`{"item":[{"name":"upload","request":{"method":"POST","url":"https://example.com","body":{"mode":"file"}}}]}`)
+	if _, err := ChecksFromPostman(data, Options{}); err == nil {
+		t.Error("want error for file body, got nil")
+	}
+}
+
+func TestChecksFromPostmanLocationsNotShared(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile("testdata/collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Locations: []string{"eu-west-1", "us-east-1"}}
+	checks, err := ChecksFromPostman(data, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks[0].Locations[0] = "ap-south-1"
+	want := []string{"eu-west-1", "us-east-1"}
+	if !cmp.Equal(want, checks[1].Locations) {
+		t.Errorf("changing one check's locations changed another's: %s", cmp.Diff(want, checks[1].Locations))
+	}
+	if !cmp.Equal(want, opts.Locations) {
+		t.Errorf("changing a check's locations changed the options: %s", cmp.Diff(want, opts.Locations))
+	}
+}
@@ -0,0 +1,8 @@
+const assert = require('chai').assert
+const puppeteer = require('puppeteer')
+
+const browser = await puppeteer.launch()
+const page = await browser.newPage()
+await page.goto('https://example.com')
+assert.equal(await page.title(), 'Example Domain')
+await browser.close()
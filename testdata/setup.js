@@ -0,0 +1 @@
+const token = await getToken();